@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+)
+
+// cliEnv holds the RLS/emulator wiring shared by every subcommand, and
+// lazily creates the admin/data clients so that a subcommand only pays for
+// the client(s) it actually needs.
+type cliEnv struct {
+	adminEndpoint     string
+	dataEndpoint      string
+	lookupServiceAddr string
+	useEmulator       bool
+	rlsCfg            rlsConfig
+	stopFakeRLS       func()
+
+	adminClient *bigtable.AdminClient
+	dataClient  *bigtable.Client
+}
+
+// newCLIEnv resolves the emulator/RLS flags, optionally starting an
+// in-process fake RLS server, and returns a cliEnv ready for subcommands to
+// pull clients from.
+func newCLIEnv() (*cliEnv, error) {
+	emuAddr := *emulatorAddr
+	if emuAddr == "" {
+		emuAddr = os.Getenv("BIGTABLE_EMULATOR_HOST")
+	}
+	useEmulator := emuAddr != ""
+
+	lookupServiceAddr := cbtRLSTestEndpoint
+	var stop func()
+	if useEmulator && *fakeRLSAddr != "" {
+		addr, s, err := startFakeRLSServer(*fakeRLSAddr, emuAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start fake RLS server: %v", err)
+		}
+		lookupServiceAddr = "dns:///" + addr
+		stop = s
+		log.Printf("Started fake RLS server on %q, resolving all lookups to %q", addr, emuAddr)
+	}
+
+	adminEndpoint := cbtAdminTestEndpoint
+	dataEndpoint := cbtDataTestEndpoint
+	if useEmulator {
+		adminEndpoint = emuAddr
+		dataEndpoint = emuAddr
+	}
+
+	return &cliEnv{
+		adminEndpoint:     adminEndpoint,
+		dataEndpoint:      dataEndpoint,
+		lookupServiceAddr: lookupServiceAddr,
+		useEmulator:       useEmulator,
+		rlsCfg: rlsConfig{
+			lookupServiceAddr: lookupServiceAddr,
+			lookupTimeout:     *rlsLookupTimeout,
+			maxAge:            *rlsMaxAge,
+			staleAge:          *rlsStaleAge,
+			cacheSizeBytes:    *rlsCacheBytes,
+		},
+		stopFakeRLS: stop,
+	}, nil
+}
+
+func (e *cliEnv) close() {
+	if e.adminClient != nil {
+		e.adminClient.Close()
+	}
+	if e.dataClient != nil {
+		e.dataClient.Close()
+	}
+	if e.stopFakeRLS != nil {
+		e.stopFakeRLS()
+	}
+}
+
+// admin returns the shared admin client, creating it on first use.
+func (e *cliEnv) admin(ctx context.Context) (*bigtable.AdminClient, error) {
+	if e.adminClient == nil {
+		c, err := createAdminClient(ctx, *projectID, *instanceID, e.adminEndpoint, e.useEmulator)
+		if err != nil {
+			return nil, fmt.Errorf("admin client creation failed: %v", err)
+		}
+		e.adminClient = c
+	}
+	return e.adminClient, nil
+}
+
+// data returns the shared data client, creating it (and its connection
+// pool) on first use.
+func (e *cliEnv) data(ctx context.Context) (*bigtable.Client, error) {
+	if e.dataClient == nil {
+		c, err := createDataClient(ctx, *projectID, *instanceID, e.dataEndpoint, *appProfile, *poolSize, *enableDefaultTarget, e.useEmulator, e.rlsCfg)
+		if err != nil {
+			return nil, fmt.Errorf("data client creation failed: %v", err)
+		}
+		e.dataClient = c
+	}
+	return e.dataClient, nil
+}
+
+func cmdCreateTable(ctx context.Context, env *cliEnv, args []string) error {
+	fs := flag.NewFlagSet("createtable", flag.ExitOnError)
+	table := fs.String("table_id", *tableID, "Cloud Bigtable table to create")
+	cf := fs.String("column_family", *columnFamily, "Column family to create on the table")
+	fs.Parse(args)
+
+	adminClient, err := env.admin(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to create table %q with columnFamily %q...\n", *table, *cf)
+	if err := createTable(ctx, adminClient, *table, *cf); err != nil {
+		return fmt.Errorf("table creation using admin client failed: %v", err)
+	}
+	log.Printf("Table %q with columnFamily %q created\n", *table, *cf)
+	return nil
+}
+
+func cmdDeleteTable(ctx context.Context, env *cliEnv, args []string) error {
+	fs := flag.NewFlagSet("deletetable", flag.ExitOnError)
+	table := fs.String("table_id", *tableID, "Cloud Bigtable table to delete")
+	fs.Parse(args)
+
+	adminClient, err := env.admin(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to delete table %q...\n", *table)
+	if err := adminClient.DeleteTable(ctx, *table); err != nil {
+		return fmt.Errorf("failed to delete table %q: %v", *table, err)
+	}
+	log.Printf("Table %q deleted\n", *table)
+	return nil
+}
+
+func cmdWrite(ctx context.Context, env *cliEnv, args []string) error {
+	fs := flag.NewFlagSet("write", flag.ExitOnError)
+	table := fs.String("table_id", *tableID, "Cloud Bigtable table to write to")
+	cf := fs.String("column_family", *columnFamily, "Cloud Bigtable column family to use")
+	cq := fs.String("column_qualifier", *columnQualifier, "Cloud Bigtable column qualifier to use")
+	prefix := fs.String("row_key_prefix", *rowKeyPrefix, "Cloud Bigtable row key prefix to use")
+	fs.Parse(args)
+
+	dataClient, err := env.data(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to write some greetings to table %q...\n", *table)
+	return writeToTable(ctx, dataClient, *table, *cf, *cq, *prefix)
+}
+
+func cmdRead(ctx context.Context, env *cliEnv, args []string) error {
+	fs := flag.NewFlagSet("read", flag.ExitOnError)
+	table := fs.String("table_id", *tableID, "Cloud Bigtable table to read from")
+	prefix := fs.String("row_key_prefix", *rowKeyPrefix, "Cloud Bigtable row key prefix to use")
+	fs.Parse(args)
+
+	dataClient, err := env.data(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to read a single row from table %q...\n", *table)
+	return readSingleRowFromTable(ctx, dataClient, *table, *prefix)
+}
+
+func cmdScan(ctx context.Context, env *cliEnv, args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	table := fs.String("table_id", *tableID, "Cloud Bigtable table to scan")
+	prefix := fs.String("row_key_prefix", *rowKeyPrefix, "Cloud Bigtable row key prefix to scan")
+	limit := fs.Int("limit", 0, "Maximum number of rows to read. 0 means no limit")
+	fs.Parse(args)
+
+	dataClient, err := env.data(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to read the entire table %q...\n", *table)
+	return readEntireTable(ctx, dataClient, *table, *prefix, *limit)
+}
+
+func cmdLookupDebug(ctx context.Context, env *cliEnv, args []string) error {
+	fs := flag.NewFlagSet("lookup-debug", flag.ExitOnError)
+	table := fs.String("table_id", *tableID, "Cloud Bigtable table to issue the debug RPC against")
+	key := fs.String("row_key", *rowKeyPrefix+"0", "Row key to issue the debug ReadRow RPC for")
+	fs.Parse(args)
+
+	return runLookupDebug(ctx, *projectID, *instanceID, env.dataEndpoint, *appProfile, env.useEmulator, env.rlsCfg, *table, *key)
+}
+
+func cmdBench(ctx context.Context, env *cliEnv, args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	table := fs.String("table_id", *tableID, "Cloud Bigtable table to benchmark against")
+	cf := fs.String("column_family", *columnFamily, "Cloud Bigtable column family to use")
+	cq := fs.String("column_qualifier", *columnQualifier, "Cloud Bigtable column qualifier to use")
+	prefix := fs.String("row_key_prefix", *rowKeyPrefix, "Cloud Bigtable row key prefix to use")
+	runFor := fs.Duration("run_for", time.Minute, "How long to drive traffic for")
+	writeQPS := fs.Float64("write_qps", 10, "Aggregate target write QPS across all workers")
+	readQPS := fs.Float64("read_qps", 10, "Aggregate target read QPS across all workers")
+	workers := fs.Int("workers", 10, "Number of concurrent workers issuing requests")
+	keyspaceSize := fs.Int("keyspace_size", 10000, "Number of distinct row keys workers pick from")
+	csvOutput := fs.String("csv_output", "", "Optional CSV file to write latency/throughput results to")
+	skipTableDeletion := fs.Bool("skip_table_deletion", false, "Whether to skip table deletion at the end")
+	fs.Parse(args)
+
+	adminClient, err := env.admin(ctx)
+	if err != nil {
+		return err
+	}
+	dataClient, err := env.data(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Attempting to create table %q with columnFamily %q...\n", *table, *cf)
+	if err := createTable(ctx, adminClient, *table, *cf); err != nil {
+		return fmt.Errorf("table creation using admin client failed: %v", err)
+	}
+	log.Printf("Table %q with columnFamily %q created\n", *table, *cf)
+
+	const tableCreationWaitDuration = 15 * time.Second
+	log.Printf("Waiting %v for table creation to take effect...", tableCreationWaitDuration)
+	time.Sleep(tableCreationWaitDuration)
+
+	cfg := loadTestConfig{
+		tableID:         *table,
+		columnFamily:    *cf,
+		columnQualifier: *cq,
+		rowKeyPrefix:    *prefix,
+		keyspaceSize:    *keyspaceSize,
+		runFor:          *runFor,
+		writeQPS:        *writeQPS,
+		readQPS:         *readQPS,
+		numWorkers:      *workers,
+		csvOutput:       *csvOutput,
+	}
+	if err := runLoadTest(ctx, dataClient, cfg); err != nil {
+		return fmt.Errorf("load test failed: %v", err)
+	}
+
+	if *skipTableDeletion {
+		return nil
+	}
+	log.Printf("Attempting to delete table %q...\n", *table)
+	if err := adminClient.DeleteTable(ctx, *table); err != nil {
+		return fmt.Errorf("failed to delete table %q: %v", *table, err)
+	}
+	log.Printf("Table %q deleted\n", *table)
+	return nil
+}
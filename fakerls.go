@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+
+	"github.com/easwars/cbt-rls-test-go/internal/rlslookup"
+)
+
+// fakeRLSServer is a minimal in-process implementation of
+// grpc.lookup.v1.RouteLookupService. It always resolves lookups to a single
+// configurable target, which is enough to exercise the RLS LB policy's
+// keybuilder extraction, caching and default-target fallback against a local
+// Bigtable emulator without depending on the CBT RLS sandbox.
+type fakeRLSServer struct {
+	rlslookup.UnimplementedRouteLookupServiceServer
+
+	mu         sync.Mutex
+	target     string
+	headerData string
+}
+
+func newFakeRLSServer(target string) *fakeRLSServer {
+	return &fakeRLSServer{target: target}
+}
+
+// setTarget updates the target returned by subsequent lookups. Exposed so
+// callers (or tests) can exercise target changes without restarting the
+// server.
+func (s *fakeRLSServer) setTarget(target string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.target = target
+}
+
+func (s *fakeRLSServer) RouteLookup(ctx context.Context, req *rlslookup.RouteLookupRequest) (*rlslookup.RouteLookupResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	log.Printf("fakeRLSServer: lookup request for key map %v, returning target %q", req.GetKeyMap(), s.target)
+	resp := rlslookup.NewRouteLookupResponse()
+	resp.SetTargets([]string{s.target})
+	resp.SetHeaderData(s.headerData)
+	return resp, nil
+}
+
+// startFakeRLSServer starts a fake RLS server listening on addr (e.g.
+// ":0" to pick a free port) that resolves every lookup to target. It
+// returns the address the server is actually listening on and a function
+// that stops the server.
+func startFakeRLSServer(addr, target string) (string, func(), error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to listen on %q: %v", addr, err)
+	}
+
+	s := grpc.NewServer()
+	rlslookup.RegisterRouteLookupServiceServer(s, newFakeRLSServer(target))
+	go func() {
+		if err := s.Serve(lis); err != nil {
+			log.Printf("fakeRLSServer: Serve returned: %v", err)
+		}
+	}()
+
+	return lis.Addr().String(), s.GracefulStop, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/easwars/cbt-rls-test-go/internal/rlslookup"
+)
+
+// TestFakeRLSServerRouteLookup exercises fakeRLSServer's RouteLookup, and
+// setTarget changing the target returned by subsequent lookups.
+func TestFakeRLSServerRouteLookup(t *testing.T) {
+	s := newFakeRLSServer("target-a")
+	req := rlslookup.NewRouteLookupRequest()
+
+	resp, err := s.RouteLookup(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RouteLookup() returned error: %v", err)
+	}
+	if got, want := resp.GetTargets(), []string{"target-a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RouteLookup() targets = %v, want %v", got, want)
+	}
+
+	s.setTarget("target-b")
+	resp, err = s.RouteLookup(context.Background(), req)
+	if err != nil {
+		t.Fatalf("RouteLookup() after setTarget() returned error: %v", err)
+	}
+	if got, want := resp.GetTargets(), []string{"target-b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RouteLookup() after setTarget() targets = %v, want %v", got, want)
+	}
+}
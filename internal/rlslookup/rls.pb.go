@@ -0,0 +1,203 @@
+// Package rlslookup is a hand-written, wire-compatible stand-in for the
+// generated code that `protoc --go_out --go-grpc_out` would produce from
+// rls.proto (see that file in this directory). It exists because
+// grpc-go's generated grpc.lookup.v1 stubs live under
+// google.golang.org/grpc/internal/proto/grpc_lookup_v1, which the Go
+// internal-package rule restricts to code rooted under
+// google.golang.org/grpc; this module cannot import it.
+//
+// The message types below are backed by google.golang.org/protobuf's
+// dynamicpb, built from a FileDescriptorProto that mirrors rls.proto
+// field-for-field, so they marshal/unmarshal to the same wire format the
+// real RLS LB policy client uses.
+package rlslookup
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	routeLookupRequestDesc  protoreflect.MessageDescriptor
+	routeLookupResponseDesc protoreflect.MessageDescriptor
+
+	fdTargetType string = "target_type"
+	fdKeyMap     string = "key_map"
+	fdHeaderData string = "header_data"
+	fdTargets    string = "targets"
+)
+
+func init() {
+	file, err := protodesc.NewFile(rlsFileDescriptorProto(), nil)
+	if err != nil {
+		panic(fmt.Sprintf("rlslookup: building file descriptor: %v", err))
+	}
+	routeLookupRequestDesc = file.Messages().ByName("RouteLookupRequest")
+	routeLookupResponseDesc = file.Messages().ByName("RouteLookupResponse")
+	if routeLookupRequestDesc == nil || routeLookupResponseDesc == nil {
+		panic("rlslookup: message descriptors not found in generated file descriptor")
+	}
+}
+
+// rlsFileDescriptorProto builds the FileDescriptorProto for rls.proto by
+// hand, field number for field number, in lieu of running protoc.
+func rlsFileDescriptorProto() *descriptorpb.FileDescriptorProto {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+	typeString := descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()
+	typeMessage := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+	typeEnum := descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum()
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("grpc/lookup/v1/rls.proto"),
+		Package: proto.String("grpc.lookup.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				// Field numbers 3-6 (with a deliberate gap at 1-2, reserved
+				// upstream for a deprecated pre-v1 wire format) match
+				// grpc-go's internal/proto/grpc_lookup_v1.RouteLookupRequest
+				// exactly; see TestWireCompatibility in rls_test.go.
+				Name: proto.String("RouteLookupRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: &fdTargetType, Number: proto.Int32(3), Label: optional, Type: typeString, JsonName: proto.String("targetType")},
+					{
+						Name: &fdKeyMap, Number: proto.Int32(4), Label: repeated, Type: typeMessage,
+						TypeName: proto.String(".grpc.lookup.v1.RouteLookupRequest.KeyMapEntry"),
+						JsonName: proto.String("keyMap"),
+					},
+					{
+						Name: proto.String("reason"), Number: proto.Int32(5), Label: optional, Type: typeEnum,
+						TypeName: proto.String(".grpc.lookup.v1.RouteLookupRequest.Reason"),
+						JsonName: proto.String("reason"),
+					},
+					{Name: proto.String("stale_header_data"), Number: proto.Int32(6), Label: optional, Type: typeString, JsonName: proto.String("staleHeaderData")},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("KeyMapEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{Name: proto.String("key"), Number: proto.Int32(1), Label: optional, Type: typeString, JsonName: proto.String("key")},
+							{Name: proto.String("value"), Number: proto.Int32(2), Label: optional, Type: typeString, JsonName: proto.String("value")},
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+				EnumType: []*descriptorpb.EnumDescriptorProto{
+					{
+						Name: proto.String("Reason"),
+						Value: []*descriptorpb.EnumValueDescriptorProto{
+							{Name: proto.String("REASON_UNKNOWN"), Number: proto.Int32(0)},
+							{Name: proto.String("REASON_MISS"), Number: proto.Int32(1)},
+							{Name: proto.String("REASON_STALE"), Number: proto.Int32(2)},
+						},
+					},
+				},
+			},
+			{
+				// header_data=2, targets=3 also match
+				// internal/proto/grpc_lookup_v1.RouteLookupResponse.
+				Name: proto.String("RouteLookupResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: &fdHeaderData, Number: proto.Int32(2), Label: optional, Type: typeString, JsonName: proto.String("headerData")},
+					{Name: &fdTargets, Number: proto.Int32(3), Label: repeated, Type: typeString, JsonName: proto.String("targets")},
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("RouteLookupService"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("RouteLookup"),
+						InputType:  proto.String(".grpc.lookup.v1.RouteLookupRequest"),
+						OutputType: proto.String(".grpc.lookup.v1.RouteLookupResponse"),
+					},
+				},
+			},
+		},
+	}
+}
+
+// RouteLookupRequest mirrors the generated type protoc-gen-go would
+// produce for the message of the same name in rls.proto.
+type RouteLookupRequest struct {
+	m *dynamicpb.Message
+}
+
+// NewRouteLookupRequest returns an empty RouteLookupRequest.
+func NewRouteLookupRequest() *RouteLookupRequest {
+	return &RouteLookupRequest{m: dynamicpb.NewMessage(routeLookupRequestDesc)}
+}
+
+func (r *RouteLookupRequest) Reset()                             { r.m = dynamicpb.NewMessage(routeLookupRequestDesc) }
+func (r *RouteLookupRequest) String() string                     { return prototext.Format(r.m) }
+func (r *RouteLookupRequest) ProtoReflect() protoreflect.Message { return r.m }
+
+// GetTargetType returns the request's target_type field.
+func (r *RouteLookupRequest) GetTargetType() string {
+	return r.m.Get(r.m.Descriptor().Fields().ByName(protoreflect.Name(fdTargetType))).String()
+}
+
+// GetKeyMap returns the request's key_map field as a plain Go map.
+func (r *RouteLookupRequest) GetKeyMap() map[string]string {
+	out := make(map[string]string)
+	fd := r.m.Descriptor().Fields().ByName(protoreflect.Name(fdKeyMap))
+	r.m.Get(fd).Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+		out[k.String()] = v.String()
+		return true
+	})
+	return out
+}
+
+// RouteLookupResponse mirrors the generated type protoc-gen-go would
+// produce for the message of the same name in rls.proto.
+type RouteLookupResponse struct {
+	m *dynamicpb.Message
+}
+
+// NewRouteLookupResponse returns an empty RouteLookupResponse.
+func NewRouteLookupResponse() *RouteLookupResponse {
+	return &RouteLookupResponse{m: dynamicpb.NewMessage(routeLookupResponseDesc)}
+}
+
+func (r *RouteLookupResponse) Reset()                             { r.m = dynamicpb.NewMessage(routeLookupResponseDesc) }
+func (r *RouteLookupResponse) String() string                     { return prototext.Format(r.m) }
+func (r *RouteLookupResponse) ProtoReflect() protoreflect.Message { return r.m }
+
+// SetHeaderData sets the response's header_data field.
+func (r *RouteLookupResponse) SetHeaderData(headerData string) {
+	fd := r.m.Descriptor().Fields().ByName(protoreflect.Name(fdHeaderData))
+	r.m.Set(fd, protoreflect.ValueOfString(headerData))
+}
+
+// SetTargets sets the response's targets field.
+func (r *RouteLookupResponse) SetTargets(targets []string) {
+	fd := r.m.Descriptor().Fields().ByName(protoreflect.Name(fdTargets))
+	list := r.m.NewField(fd).List()
+	for _, target := range targets {
+		list.Append(protoreflect.ValueOfString(target))
+	}
+	r.m.Set(fd, protoreflect.ValueOfList(list))
+}
+
+// GetHeaderData returns the response's header_data field.
+func (r *RouteLookupResponse) GetHeaderData() string {
+	return r.m.Get(r.m.Descriptor().Fields().ByName(protoreflect.Name(fdHeaderData))).String()
+}
+
+// GetTargets returns the response's targets field.
+func (r *RouteLookupResponse) GetTargets() []string {
+	list := r.m.Get(r.m.Descriptor().Fields().ByName(protoreflect.Name(fdTargets))).List()
+	out := make([]string, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		out[i] = list.Get(i).String()
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package rlslookup
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const routeLookupServiceName = "grpc.lookup.v1.RouteLookupService"
+
+// RouteLookupServiceServer is the server API for RouteLookupService, as
+// protoc-gen-go-grpc would generate it from rls.proto. Only the server side
+// is modeled here: the client in this repo's setup is always the real RLS
+// LB policy built into google.golang.org/grpc/balancer/rls.
+type RouteLookupServiceServer interface {
+	RouteLookup(context.Context, *RouteLookupRequest) (*RouteLookupResponse, error)
+}
+
+// UnimplementedRouteLookupServiceServer can be embedded to have forward
+// compatible implementations.
+type UnimplementedRouteLookupServiceServer struct{}
+
+func (UnimplementedRouteLookupServiceServer) RouteLookup(context.Context, *RouteLookupRequest) (*RouteLookupResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RouteLookup not implemented")
+}
+
+// RegisterRouteLookupServiceServer registers srv to handle
+// grpc.lookup.v1.RouteLookupService RPCs on s.
+func RegisterRouteLookupServiceServer(s grpc.ServiceRegistrar, srv RouteLookupServiceServer) {
+	s.RegisterService(&routeLookupServiceDesc, srv)
+}
+
+func _RouteLookupService_RouteLookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := NewRouteLookupRequest()
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RouteLookupServiceServer).RouteLookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + routeLookupServiceName + "/RouteLookup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RouteLookupServiceServer).RouteLookup(ctx, req.(*RouteLookupRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var routeLookupServiceDesc = grpc.ServiceDesc{
+	ServiceName: routeLookupServiceName,
+	HandlerType: (*RouteLookupServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RouteLookup",
+			Handler:    _RouteLookupService_RouteLookup_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "grpc/lookup/v1/rls.proto",
+}
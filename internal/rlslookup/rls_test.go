@@ -0,0 +1,82 @@
+package rlslookup
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TestWireCompatibility pins the field numbers this package puts on the wire
+// against the ones grpc-go's internal/proto/grpc_lookup_v1 package actually
+// uses (verified against google.golang.org/grpc v1.60.1's generated
+// rls.pb.go, since that package cannot be imported directly from here). A
+// mismatch here means a message built by this package would decode into the
+// wrong fields for the real RLS LB policy client, silently breaking
+// keybuilder extraction or target resolution.
+func TestWireCompatibility(t *testing.T) {
+	req := NewRouteLookupRequest()
+	desc := req.m.Descriptor()
+	req.m.Set(desc.Fields().ByName(protoreflect.Name(fdTargetType)), protoreflect.ValueOfString("grpc"))
+	keyMap := req.m.NewField(desc.Fields().ByName(protoreflect.Name(fdKeyMap))).Map()
+	keyMap.Set(protoreflect.ValueOfString("k").MapKey(), protoreflect.ValueOfString("v"))
+	req.m.Set(desc.Fields().ByName(protoreflect.Name(fdKeyMap)), protoreflect.ValueOfMap(keyMap))
+	req.m.Set(desc.Fields().ByName("reason"), protoreflect.ValueOfEnum(1)) // REASON_MISS
+	req.m.Set(desc.Fields().ByName("stale_header_data"), protoreflect.ValueOfString("stale"))
+
+	reqBytes, err := proto.Marshal(req)
+	if err != nil {
+		t.Fatalf("proto.Marshal(request): %v", err)
+	}
+	wantReqFields := map[protowire.Number]bool{3: true, 4: true, 5: true, 6: true}
+	if got := topLevelFieldNumbers(t, reqBytes); !sameFieldSet(got, wantReqFields) {
+		t.Errorf("RouteLookupRequest wire field numbers = %v, want %v (target_type=3, key_map=4, reason=5, stale_header_data=6, matching grpc-go's internal/proto/grpc_lookup_v1.RouteLookupRequest)", got, wantReqFields)
+	}
+
+	resp := NewRouteLookupResponse()
+	resp.SetHeaderData("header")
+	resp.SetTargets([]string{"target-a"})
+
+	respBytes, err := proto.Marshal(resp)
+	if err != nil {
+		t.Fatalf("proto.Marshal(response): %v", err)
+	}
+	wantRespFields := map[protowire.Number]bool{2: true, 3: true}
+	if got := topLevelFieldNumbers(t, respBytes); !sameFieldSet(got, wantRespFields) {
+		t.Errorf("RouteLookupResponse wire field numbers = %v, want %v (header_data=2, targets=3, matching grpc-go's internal/proto/grpc_lookup_v1.RouteLookupResponse)", got, wantRespFields)
+	}
+}
+
+// topLevelFieldNumbers walks the top-level fields of a marshaled proto
+// message and returns the set of field numbers present on the wire.
+func topLevelFieldNumbers(t *testing.T, b []byte) map[protowire.Number]bool {
+	t.Helper()
+	out := make(map[protowire.Number]bool)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("protowire.ConsumeTag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+		out[num] = true
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			t.Fatalf("protowire.ConsumeFieldValue: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return out
+}
+
+func sameFieldSet(got, want map[protowire.Number]bool) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for num := range want {
+		if !got[num] {
+			return false
+		}
+	}
+	return true
+}
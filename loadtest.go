@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigtable"
+)
+
+// opResult carries the outcome of a single Apply/ReadRow call back to the
+// stats collector.
+type opResult struct {
+	method  string // "write" or "read"
+	latency time.Duration
+	err     error
+}
+
+// methodStats accumulates latencies and success/failure counts for one
+// method over the course of a load test run.
+type methodStats struct {
+	latencies []time.Duration
+	successes int64
+	failures  int64
+}
+
+func (s *methodStats) record(r opResult) {
+	if r.err != nil {
+		s.failures++
+		return
+	}
+	s.successes++
+	s.latencies = append(s.latencies, r.latency)
+}
+
+// percentile returns the p-th percentile (0 <= p <= 100) of a sorted
+// latencies slice. latencies must be sorted in ascending order.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(p / 100 * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+func (s *methodStats) summarize(runFor time.Duration) (min, median, p90, p95, p99, max time.Duration, throughput float64) {
+	if len(s.latencies) == 0 {
+		return
+	}
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	min = sorted[0]
+	max = sorted[len(sorted)-1]
+	median = percentile(sorted, 50)
+	p90 = percentile(sorted, 90)
+	p95 = percentile(sorted, 95)
+	p99 = percentile(sorted, 99)
+	throughput = float64(s.successes) / runFor.Seconds()
+	return
+}
+
+// loadTestConfig bundles the knobs needed to drive a load test run.
+type loadTestConfig struct {
+	tableID         string
+	columnFamily    string
+	columnQualifier string
+	rowKeyPrefix    string
+	keyspaceSize    int
+	runFor          time.Duration
+	writeQPS        float64
+	readQPS         float64
+	numWorkers      int
+	csvOutput       string
+}
+
+// runLoadTest drives sustained read/write traffic against table for the
+// configured duration, then prints (and optionally writes to a CSV file)
+// per-method latency percentiles and throughput. This is modeled on the
+// cloud.google.com/go/bigtable loadtest utility and is meant to surface RLS
+// cache warm/cold behavior under sustained concurrent load.
+func runLoadTest(ctx context.Context, client *bigtable.Client, cfg loadTestConfig) error {
+	table := client.Open(cfg.tableID)
+	if table == nil {
+		return fmt.Errorf("failed to open table %q", cfg.tableID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.runFor)
+	defer cancel()
+
+	results := make(chan opResult, 1024)
+	var wg sync.WaitGroup
+
+	writeWorkers, readWorkers := splitWorkers(cfg.numWorkers, cfg.writeQPS, cfg.readQPS)
+	log.Printf("Starting load test: %d write worker(s) at %.1f qps, %d read worker(s) at %.1f qps, for %v",
+		writeWorkers, cfg.writeQPS, readWorkers, cfg.readQPS, cfg.runFor)
+
+	startWorker := func(method string, qps float64, op func(key string) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			interval := qpsToInterval(qps)
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					key := randomRowKey(cfg.rowKeyPrefix, cfg.keyspaceSize)
+					start := time.Now()
+					err := op(key)
+					results <- opResult{method: method, latency: time.Since(start), err: err}
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < writeWorkers; i++ {
+		startWorker("write", cfg.writeQPS/float64(writeWorkers), func(key string) error {
+			mutation := bigtable.NewMutation()
+			mutation.Set(cfg.columnFamily, cfg.columnQualifier, bigtable.Now(), []byte("loadtest"))
+			return table.Apply(ctx, key, mutation)
+		})
+	}
+	for i := 0; i < readWorkers; i++ {
+		startWorker("read", cfg.readQPS/float64(readWorkers), func(key string) error {
+			_, err := table.ReadRow(ctx, key)
+			return err
+		})
+	}
+
+	stats := map[string]*methodStats{"write": {}, "read": {}}
+	done := make(chan struct{})
+	go func() {
+		for r := range results {
+			stats[r.method].record(r)
+		}
+		close(done)
+	}()
+
+	wg.Wait()
+	close(results)
+	<-done
+
+	return reportLoadTestResults(stats, cfg.runFor, cfg.csvOutput)
+}
+
+// splitWorkers divides numWorkers between writes and reads in proportion to
+// their target QPS. When numWorkers is at least 2, each side with a positive
+// QPS gets at least one worker; with a single worker, only the side with the
+// larger QPS gets it. The two returned counts always sum to numWorkers (when
+// numWorkers is at least 1).
+func splitWorkers(numWorkers int, writeQPS, readQPS float64) (writeWorkers, readWorkers int) {
+	if writeQPS <= 0 {
+		return 0, numWorkers
+	}
+	if readQPS <= 0 {
+		return numWorkers, 0
+	}
+	if numWorkers <= 1 {
+		// Not enough workers to give both sides one; hand the only worker to
+		// whichever side wants more QPS.
+		if writeQPS >= readQPS {
+			return numWorkers, 0
+		}
+		return 0, numWorkers
+	}
+	writeWorkers = int(float64(numWorkers) * writeQPS / (writeQPS + readQPS))
+	if writeWorkers < 1 {
+		writeWorkers = 1
+	}
+	if writeWorkers > numWorkers-1 {
+		writeWorkers = numWorkers - 1
+	}
+	readWorkers = numWorkers - writeWorkers
+	return writeWorkers, readWorkers
+}
+
+// qpsToInterval converts a target per-worker QPS into a ticker interval.
+// The result is clamped to at least 1ns so that a large enough qps can't
+// turn it into a non-positive duration, which would make the
+// time.NewTicker in runLoadTest panic.
+func qpsToInterval(qps float64) time.Duration {
+	if qps <= 0 {
+		return time.Second
+	}
+	if interval := time.Duration(float64(time.Second) / qps); interval > 0 {
+		return interval
+	}
+	return 1
+}
+
+// randomRowKey picks a row key from the generated keyspace
+// [rowKeyPrefix+"0", rowKeyPrefix+(keyspaceSize-1)]. It uses the
+// concurrency-safe top-level rand functions rather than constructing a new
+// *rand.Rand per call, which would be wasteful under load.
+func randomRowKey(rowKeyPrefix string, keyspaceSize int) string {
+	if keyspaceSize <= 0 {
+		keyspaceSize = 1
+	}
+	return rowKeyPrefix + strconv.Itoa(rand.Intn(keyspaceSize))
+}
+
+// reportLoadTestResults prints per-method latency percentiles and
+// throughput to stdout, and writes them to csvOutput as well if set.
+func reportLoadTestResults(stats map[string]*methodStats, runFor time.Duration, csvOutput string) error {
+	methods := []string{"write", "read"}
+	header := []string{"method", "count", "errors", "min", "median", "p90", "p95", "p99", "max", "qps"}
+
+	rows := make([][]string, 0, len(methods))
+	for _, method := range methods {
+		s := stats[method]
+		min, median, p90, p95, p99, max, throughput := s.summarize(runFor)
+		rows = append(rows, []string{
+			method,
+			strconv.FormatInt(s.successes, 10),
+			strconv.FormatInt(s.failures, 10),
+			min.String(),
+			median.String(),
+			p90.String(),
+			p95.String(),
+			p99.String(),
+			max.String(),
+			fmt.Sprintf("%.2f", throughput),
+		})
+	}
+
+	if err := writeCSV(os.Stdout, header, rows); err != nil {
+		return err
+	}
+
+	if csvOutput == "" {
+		return nil
+	}
+	f, err := os.Create(csvOutput)
+	if err != nil {
+		return fmt.Errorf("failed to create csv output file %q: %v", csvOutput, err)
+	}
+	defer f.Close()
+	return writeCSV(f, header, rows)
+}
+
+// writeCSV writes header followed by rows to w in CSV format.
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	return cw.Error()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{
+		1 * time.Millisecond,
+		2 * time.Millisecond,
+		3 * time.Millisecond,
+		4 * time.Millisecond,
+		5 * time.Millisecond,
+	}
+	tests := []struct {
+		name string
+		p    float64
+		want time.Duration
+	}{
+		{name: "min", p: 0, want: 1 * time.Millisecond},
+		{name: "median", p: 50, want: 3 * time.Millisecond},
+		{name: "max", p: 100, want: 5 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := percentile(sorted, test.p); got != test.want {
+				t.Errorf("percentile(sorted, %v) = %v, want %v", test.p, got, test.want)
+			}
+		})
+	}
+
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile(nil, 50) = %v, want 0", got)
+	}
+}
+
+func TestSplitWorkers(t *testing.T) {
+	tests := []struct {
+		name              string
+		numWorkers        int
+		writeQPS, readQPS float64
+		wantWrite         int
+		wantRead          int
+	}{
+		{name: "write only", numWorkers: 10, writeQPS: 10, readQPS: 0, wantWrite: 10, wantRead: 0},
+		{name: "read only", numWorkers: 10, writeQPS: 0, readQPS: 10, wantWrite: 0, wantRead: 10},
+		{name: "even split", numWorkers: 10, writeQPS: 10, readQPS: 10, wantWrite: 5, wantRead: 5},
+		{name: "single worker favors larger qps", numWorkers: 1, writeQPS: 10, readQPS: 1, wantWrite: 1, wantRead: 0},
+		{name: "single worker favors reads", numWorkers: 1, writeQPS: 1, readQPS: 10, wantWrite: 0, wantRead: 1},
+		{name: "small pool still sums to numWorkers", numWorkers: 2, writeQPS: 1, readQPS: 1000, wantWrite: 1, wantRead: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotWrite, gotRead := splitWorkers(test.numWorkers, test.writeQPS, test.readQPS)
+			if gotWrite != test.wantWrite || gotRead != test.wantRead {
+				t.Errorf("splitWorkers(%d, %v, %v) = (%d, %d), want (%d, %d)",
+					test.numWorkers, test.writeQPS, test.readQPS, gotWrite, gotRead, test.wantWrite, test.wantRead)
+			}
+			if gotWrite+gotRead != test.numWorkers {
+				t.Errorf("splitWorkers(%d, %v, %v) summed to %d, want %d",
+					test.numWorkers, test.writeQPS, test.readQPS, gotWrite+gotRead, test.numWorkers)
+			}
+		})
+	}
+}
+
+func TestQPSToInterval(t *testing.T) {
+	tests := []struct {
+		name string
+		qps  float64
+		want time.Duration
+	}{
+		{name: "zero", qps: 0, want: time.Second},
+		{name: "negative", qps: -1, want: time.Second},
+		{name: "one qps", qps: 1, want: time.Second},
+		{name: "ten qps", qps: 10, want: 100 * time.Millisecond},
+		{name: "huge qps clamps to 1ns instead of going non-positive", qps: 1e18, want: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := qpsToInterval(test.qps)
+			if got != test.want {
+				t.Errorf("qpsToInterval(%v) = %v, want %v", test.qps, got, test.want)
+			}
+			if got <= 0 {
+				t.Errorf("qpsToInterval(%v) = %v, want a positive duration (time.NewTicker panics otherwise)", test.qps, got)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"time"
 
 	"cloud.google.com/go/bigtable"
@@ -12,6 +14,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/google"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 
 	_ "google.golang.org/grpc/balancer/rls" // Register the RLS LB policy
@@ -26,7 +29,15 @@ var (
 	rowKeyPrefix        = flag.String("row_key_prefix", "row_key_", "Cloud Bigtable row key to use")
 	appProfile          = flag.String("app_profile", "", "Cloud Bigtable application profile to use. If unspecified, the default app profile will be used")
 	enableDefaultTarget = flag.Bool("enable_default_target", false, "Whether to set a default target in the service config")
-	skipTableDeletion   = flag.Bool("skip_table_deletion", false, "Whether to skip table deletion at the end")
+
+	emulatorAddr = flag.String("emulator_addr", "", "Address of a Cloud Bigtable emulator to run against instead of the CBT sandbox. If unset, falls back to the BIGTABLE_EMULATOR_HOST environment variable")
+	fakeRLSAddr  = flag.String("fake_rls_addr", "", "If set (e.g. \":0\" to pick a free port), starts an in-process fake RLS server on this address and points the RLS LB policy at it instead of the CBT RLS sandbox. Only takes effect when running against an emulator")
+
+	poolSize         = flag.Int("pool_size", 1, "Number of gRPC sub-connections to pool for the data client")
+	rlsCacheBytes    = flag.Int64("rls_cache_bytes", 1000, "Size, in bytes, of the RLS LB policy's target cache")
+	rlsMaxAge        = flag.Duration("rls_max_age", 300*time.Second, "Maximum age of an entry in the RLS LB policy's cache")
+	rlsStaleAge      = flag.Duration("rls_stale_age", 240*time.Second, "Age at which an RLS cache entry is considered stale and eligible for a background refresh")
+	rlsLookupTimeout = flag.Duration("rls_lookup_timeout", 10*time.Second, "Timeout for a single RouteLookup RPC to the RLS server")
 )
 
 const (
@@ -38,7 +49,9 @@ const (
 	// Service config for the RLS LB policy.
 	//
 	// `lookupService` and `defaultTarget` are to be filled in with the value of
-	// the RLS server and the default target respectively.
+	// the RLS server and the default target respectively. `lookupServiceTimeout`,
+	// `maxAge`, `staleAge` and `cacheSizeBytes` are filled in from the
+	// corresponding -rls_* flags.
 	//
 	// Also contains service config for the gRPC channel to the RLS server. This
 	// is required since the CBT RLS server implementation is only available via
@@ -74,10 +87,10 @@ const (
             }
           ],
           "lookupService": "%s",
-          "lookupServiceTimeout" : "10s",
-          "maxAge": "300s",
-          "staleAge" : "240s",
-          "cacheSizeBytes": 1000,
+          "lookupServiceTimeout" : "%s",
+          "maxAge": "%s",
+          "staleAge" : "%s",
+          "cacheSizeBytes": %d,
           "defaultTarget": "%s"
         },
         "routeLookupChannelServiceConfig": {
@@ -101,76 +114,147 @@ const (
 }`
 )
 
+const usage = `cbt-rls-test-go is a diagnostic tool for exercising the RLS LB policy against Cloud Bigtable.
+
+Usage:
+  cbt-rls-test-go [global flags] <command> [command flags]
+
+Commands:
+  createtable    create the table (and column family) used by the other commands
+  write          write a few greetings to the table
+  read           read a single row from the table
+  scan           read all rows (optionally a limited number) matching a row key prefix
+  deletetable    delete the table
+  lookup-debug   issue a single RPC and log the RLS keybuilder headers and the peer address it was routed to
+  bench          create the table and drive sustained read/write traffic against it, reporting latency percentiles
+
+Run "cbt-rls-test-go <command> -help" to see flags for a specific command.
+`
+
 func main() {
 	flag.Parse()
-	log.Printf("Running CBT RLS Test on project %q and instance %q...", *projectID, *instanceID)
 
-	ctx := context.Background()
-	adminClient, err := bigtable.NewAdminClient(ctx, *projectID, *instanceID, option.WithEndpoint(cbtAdminTestEndpoint))
-	if err != nil {
-		log.Fatalf("Bigtable admin client creation failed: %v", err)
+	args := flag.Args()
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
-	defer adminClient.Close()
 
-	dataClient, err := createDataClient(ctx, *projectID, *instanceID, cbtDataTestEndpoint, *appProfile, *enableDefaultTarget)
+	env, err := newCLIEnv()
 	if err != nil {
-		log.Fatalf("Bigtable data client creation failed: %v", err)
+		log.Fatalf("Failed to set up environment: %v", err)
 	}
-	defer dataClient.Close()
+	defer env.close()
 
-	log.Printf("Attempting to create table %q with columnFamily %q...\n", *tableID, *columnFamily)
-	if err := createTable(ctx, adminClient, *tableID, *columnFamily); err != nil {
-		log.Fatalf("Table creation using admin client failed: %v", err)
+	cmd, rest := args[0], args[1:]
+	switch cmd {
+	case "createtable":
+		err = cmdCreateTable(context.Background(), env, rest)
+	case "write":
+		err = cmdWrite(context.Background(), env, rest)
+	case "read":
+		err = cmdRead(context.Background(), env, rest)
+	case "scan":
+		err = cmdScan(context.Background(), env, rest)
+	case "deletetable":
+		err = cmdDeleteTable(context.Background(), env, rest)
+	case "lookup-debug":
+		err = cmdLookupDebug(context.Background(), env, rest)
+	case "bench":
+		err = cmdBench(context.Background(), env, rest)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
 	}
-	log.Printf("Table %q with columnFamily %q created\n", *tableID, *columnFamily)
-
-	const tableCreationWaitDuration = 15 * time.Second
-	log.Printf("Waiting %v for table creation to take effect...", tableCreationWaitDuration)
-	time.Sleep(tableCreationWaitDuration)
-
-	log.Printf("Attempting to write some greetings to table %q...\n", *tableID)
-	if err := writeToTable(ctx, dataClient, *tableID, *columnFamily, *columnQualifier, *rowKeyPrefix); err != nil {
-		log.Fatalf("Writing to table using data client failed: %v", err)
-	}
-
-	log.Printf("Attempting to read a single row from table %q...\n", *tableID)
-	if err := readSingleRowFromTable(ctx, dataClient, *tableID, *rowKeyPrefix); err != nil {
-		log.Fatalf("Reading single row from table using data client failed: %v", err)
+	if err != nil {
+		log.Fatalf("%s: %v", cmd, err)
 	}
+}
 
-	log.Printf("Attempting to read the entire table %q...\n", *tableID)
-	if err := readEntireTable(ctx, dataClient, *tableID, *rowKeyPrefix); err != nil {
-		log.Fatalf("Reading entrire table using data client failed: %v", err)
+// createAdminClient builds the admin client used to create/delete tables and
+// column families. When useEmulator is true, endpoint is expected to be the
+// address of a local Bigtable emulator, and the client connects to it over
+// an insecure, unauthenticated channel instead of application default
+// credentials.
+func createAdminClient(ctx context.Context, project, instance, endpoint string, useEmulator bool) (*bigtable.AdminClient, error) {
+	if !useEmulator {
+		return bigtable.NewAdminClient(ctx, project, instance, option.WithEndpoint(endpoint))
 	}
+	return bigtable.NewAdminClient(ctx, project, instance,
+		option.WithEndpoint(endpoint),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		option.WithoutAuthentication(),
+	)
+}
 
-	if *skipTableDeletion {
-		return
-	}
+// rlsConfig bundles the RLS LB policy knobs that get templated into
+// serviceConfigTmpl.
+type rlsConfig struct {
+	lookupServiceAddr string
+	lookupTimeout     time.Duration
+	maxAge            time.Duration
+	staleAge          time.Duration
+	cacheSizeBytes    int64
+}
 
-	log.Printf("Attempting to delete table %q...\n", *tableID)
-	if err := adminClient.DeleteTable(ctx, *tableID); err != nil {
-		log.Fatalf("Failed to delete table %q: %v", *tableID, err)
-	}
-	log.Printf("Table %q deleted\n", *tableID)
+// durationToRLSString renders d the way the RLS service config JSON expects
+// durations: a bare number of seconds followed by "s". Fixed-point
+// formatting is used instead of "%g" so that very large or very small
+// durations don't come out in scientific notation, which isn't valid
+// Duration JSON.
+func durationToRLSString(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
 }
 
-func createDataClient(ctx context.Context, project, instance, endpoint, appProfile string, enableDefaultTarget bool) (*bigtable.Client, error) {
+// createDataClient builds the data client used for Apply/ReadRow/ReadRows
+// calls, configured to route through the RLS LB policy. rlsCfg.lookupServiceAddr
+// is the RLS server the policy should query; it is either the CBT RLS
+// sandbox or, when running against an emulator with -fake_rls_addr set, an
+// in-process fake RLS server. When useEmulator is true, endpoint is expected
+// to be the address of a local Bigtable emulator, and the channel is dialed
+// without TLS or application default credentials. poolSize gRPC
+// sub-connections are dialed and handed to the Bigtable client as a pool, so
+// that RLS LB policy behavior can be characterized across multiple
+// sub-channels under concurrent load.
+func createDataClient(ctx context.Context, project, instance, endpoint, appProfile string, poolSize int, enableDefaultTarget, useEmulator bool, rlsCfg rlsConfig) (*bigtable.Client, error) {
 	defaultTarget := ""
 	if enableDefaultTarget {
 		defaultTarget = rlsDefaultTarget
 	}
-	serviceConfig := fmt.Sprintf(serviceConfigTmpl, cbtRLSTestEndpoint, defaultTarget)
-	cc, err := grpc.Dial(endpoint,
+	serviceConfig := fmt.Sprintf(serviceConfigTmpl,
+		rlsCfg.lookupServiceAddr,
+		durationToRLSString(rlsCfg.lookupTimeout),
+		durationToRLSString(rlsCfg.maxAge),
+		durationToRLSString(rlsCfg.staleAge),
+		rlsCfg.cacheSizeBytes,
+		defaultTarget,
+	)
+	dialOpts := []grpc.DialOption{
 		grpc.WithDisableServiceConfig(),
 		grpc.WithDefaultServiceConfig(serviceConfig),
-		grpc.WithCredentialsBundle(google.NewDefaultCredentials()),
 		grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(1<<28), grpc.MaxCallRecvMsgSize(1<<28)),
-	)
-	if err != nil {
-		return nil, err
+	}
+	if useEmulator {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithCredentialsBundle(google.NewDefaultCredentials()))
 	}
 
-	opts := []option.ClientOption{option.WithGRPCConn(cc)}
+	if poolSize < 1 {
+		poolSize = 1
+	}
+	// option.WithGRPCConnectionPool (rather than dialing poolSize connections
+	// and calling option.WithGRPCConn once per connection, which only ever
+	// keeps the last one) is what actually builds a round-robin pool of
+	// sub-connections for the client to use.
+	opts := []option.ClientOption{
+		option.WithEndpoint(endpoint),
+		option.WithGRPCConnectionPool(poolSize),
+		option.WithoutAuthentication(),
+	}
+	for _, d := range dialOpts {
+		opts = append(opts, option.WithGRPCDialOption(d))
+	}
 	if appProfile != "" {
 		return bigtable.NewClientWithConfig(ctx, project, instance, bigtable.ClientConfig{AppProfile: appProfile}, opts...)
 	}
@@ -240,7 +324,9 @@ func readSingleRowFromTable(ctx context.Context, client *bigtable.Client, tableI
 	return nil
 }
 
-func readEntireTable(ctx context.Context, client *bigtable.Client, tableID, rowKeyPrefix string) error {
+// readEntireTable reads every row whose key has the given prefix, or at
+// most limit rows if limit is positive.
+func readEntireTable(ctx context.Context, client *bigtable.Client, tableID, rowKeyPrefix string, limit int) error {
 	table := client.Open(tableID)
 	if table == nil {
 		return fmt.Errorf("failed to open table %q", tableID)
@@ -254,7 +340,12 @@ func readEntireTable(ctx context.Context, client *bigtable.Client, tableID, rowK
 		}
 		return true
 	}
-	if err := table.ReadRows(ctx, bigtable.PrefixRange(rowKeyPrefix), printRow); err != nil {
+
+	var opts []bigtable.ReadOption
+	if limit > 0 {
+		opts = append(opts, bigtable.LimitRows(int64(limit)))
+	}
+	if err := table.ReadRows(ctx, bigtable.PrefixRange(rowKeyPrefix), printRow, opts...); err != nil {
 		return fmt.Errorf("failed to read rows from table %q: %v", tableID, err)
 	}
 
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDurationToRLSString(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{name: "typical", d: 300 * time.Second, want: "300s"},
+		{name: "sub-second", d: 500 * time.Millisecond, want: "0.5s"},
+		{name: "large", d: 1_000_000 * time.Second, want: "1000000s"},
+		{name: "tiny", d: 50 * time.Microsecond, want: "0.00005s"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := durationToRLSString(test.d); got != test.want {
+				t.Errorf("durationToRLSString(%v) = %q, want %q", test.d, got, test.want)
+			}
+		})
+	}
+}